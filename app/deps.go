@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Redis 是 slowHandler 的一个可选下游依赖：把邮箱正则校验的结果按输入缓存起来，
+// 用来在调用链里多一层真实的依赖，也方便观察缓存命中率。
+//   - DEMO_REDIS_ENABLED：是否启用，默认 false（不引入这个下游依赖）。
+//   - DEMO_REDIS_ADDR：Redis 地址，默认 localhost:6379。
+//   - DEMO_REDIS_CACHE_TTL：缓存结果的 TTL，默认 30s。
+const (
+	envRedisEnabled  = "DEMO_REDIS_ENABLED"
+	envRedisAddr     = "DEMO_REDIS_ADDR"
+	envRedisCacheTTL = "DEMO_REDIS_CACHE_TTL"
+
+	defaultRedisAddr     = "localhost:6379"
+	defaultRedisCacheTTL = 30 * time.Second
+
+	emailCacheKeyPrefix = "demo:email_check:"
+)
+
+var (
+	redisClient *redis.Client
+	redisTTL    time.Duration
+
+	cacheHitCounter  metric.Int64Counter
+	cacheMissCounter metric.Int64Counter
+	redisDuration    metric.Float64Histogram
+)
+
+// redisEnabled 返回 DEMO_REDIS_ENABLED 是否为真值。
+func redisEnabled() bool {
+	return os.Getenv(envRedisEnabled) == "true" || os.Getenv(envRedisEnabled) == "1"
+}
+
+// initRedis 在 redisEnabled() 为真时初始化一个带 otel 埋点的 Redis 客户端，
+// 并注册 demo_email_cache_{hit,miss}_total 计数器和 demo_redis_query_duration_seconds 直方图。
+func initRedis() error {
+	if !redisEnabled() {
+		return nil
+	}
+
+	addr := os.Getenv(envRedisAddr)
+	if addr == "" {
+		addr = defaultRedisAddr
+	}
+	redisTTL = defaultRedisCacheTTL
+	if raw := os.Getenv(envRedisCacheTTL); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil {
+			redisTTL = d
+		}
+	}
+
+	redisClient = redis.NewClient(&redis.Options{Addr: addr})
+	if err := redisotel.InstrumentTracing(redisClient); err != nil {
+		return fmt.Errorf("failed to instrument redis tracing: %w", err)
+	}
+	if err := redisotel.InstrumentMetrics(redisClient); err != nil {
+		return fmt.Errorf("failed to instrument redis metrics: %w", err)
+	}
+
+	var err error
+	cacheHitCounter, err = meter.Int64Counter("demo_email_cache_hit_total", metric.WithDescription("Email-check cache hits"))
+	if err != nil {
+		return err
+	}
+	cacheMissCounter, err = meter.Int64Counter("demo_email_cache_miss_total", metric.WithDescription("Email-check cache misses"))
+	if err != nil {
+		return err
+	}
+	redisDuration, err = meter.Float64Histogram("demo_redis_query_duration_seconds", metric.WithDescription("Redis round-trip duration"))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("redis cache enabled, addr=%s ttl=%s", addr, redisTTL)
+	return nil
+}
+
+// checkEmailCached 和 checkEmail 效果一致（返回邮箱正则是否匹配），但会先查 Redis 缓存；
+// 没有命中才真的跑一遍 checkEmail，并把结果写回缓存。没启用 Redis 时直接退化为 checkEmail。
+func checkEmailCached(ctx context.Context, input string) bool {
+	if redisClient == nil {
+		return checkEmail()
+	}
+
+	key := emailCacheKeyPrefix + input
+	start := time.Now()
+	val, err := redisClient.Get(ctx, key).Result()
+	redisDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attribute.String("op", "get")))
+
+	if err == nil {
+		cacheHitCounter.Add(ctx, 1)
+		return val == "1"
+	}
+	if err != redis.Nil {
+		log.Printf("redis GET failed, falling back to direct check: %v", err)
+		return checkEmail()
+	}
+
+	cacheMissCounter.Add(ctx, 1)
+	matched := checkEmail()
+
+	value := "0"
+	if matched {
+		value = "1"
+	}
+	start = time.Now()
+	if err := redisClient.Set(ctx, key, value, redisTTL).Err(); err != nil {
+		log.Printf("redis SET failed: %v", err)
+	}
+	redisDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attribute.String("op", "set")))
+
+	return matched
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	echopb "github.com/grafana/oteldemo/proto/echo"
+)
+
+// grpcAddr 是下游 gRPC 服务监听的地址。它和 HTTP 的 8080 端口是两个独立的进程内"服务"，
+// 目的是在 Tempo 里能看到流量发生器 -> gRPC 的跨服务调用链。
+const grpcAddr = ":9090"
+
+// echoServer 实现 echopb.EchoServiceServer，逻辑上对应 HTTP 的 /hello /slow /alloc 三个接口，
+// 这样同一套"业务逻辑"可以同时通过 HTTP 和 gRPC 两条路径观察。
+type echoServer struct {
+	echopb.UnimplementedEchoServiceServer
+}
+
+func (s *echoServer) Fast(ctx context.Context, req *echopb.FastRequest) (*echopb.FastReply, error) {
+	_, span := tracer.Start(ctx, "grpc_fast_business_logic")
+	defer span.End()
+	return &echopb.FastReply{Message: "Hello from gRPC"}, nil
+}
+
+func (s *echoServer) Slow(ctx context.Context, req *echopb.SlowRequest) (*echopb.SlowReply, error) {
+	_, span := tracer.Start(ctx, "grpc_slow_business_logic")
+	defer span.End()
+
+	iterations := int(req.GetIterations())
+	if iterations <= 0 {
+		iterations = 50
+	}
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		_ = checkEmail()
+	}
+	return &echopb.SlowReply{
+		Message:    "Slow RPC finished",
+		DurationMs: time.Since(start).Milliseconds(),
+	}, nil
+}
+
+func (s *echoServer) Alloc(ctx context.Context, req *echopb.AllocRequest) (*echopb.AllocReply, error) {
+	_, span := tracer.Start(ctx, "grpc_alloc_business_logic")
+	defer span.End()
+
+	// 和 HTTP /alloc 一样，每次固定分配一批 256KB × 200 的内存；AllocRequest 里的
+	// chunk_kb / chunk_count 不影响分配量，bytes_allocated 报告的是这批内存的实际大小。
+	allocateMemoryBurst()
+	return &echopb.AllocReply{
+		Message:        "Alloc RPC finished",
+		BytesAllocated: 256 * 1024 * 200,
+	}, nil
+}
+
+// startGRPCServer 启动下游 gRPC 服务（第二个"进程"，这里用同一个二进制内的 goroutine
+// 加独立端口来模拟），并注册 otelgrpc 的拦截器，让 gRPC 调用自动加入当前的 trace。
+// tracer provider 本身已经在 initProvider 中被 otelpyroscope 包装过，所以这里不用重复处理，
+// gRPC span 同样会携带 pyroscope.profile.id 等 profiling 关联属性。
+func startGRPCServer() {
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatalf("failed to listen on %s: %v", grpcAddr, err)
+	}
+
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	)
+	echopb.RegisterEchoServiceServer(srv, &echoServer{})
+
+	go func() {
+		log.Printf("gRPC server listening on %s", grpcAddr)
+		if err := srv.Serve(lis); err != nil {
+			log.Fatalf("gRPC server stopped: %v", err)
+		}
+	}()
+}
+
+// runGRPCIteration 是 startTrafficGenerator 里 gRPC 分支的实现：调用下游 EchoService.Slow，
+// 并记录请求计数 / 耗时直方图，route 标签用 "grpc:/Slow" 以便区分。出错时只用标准库 log 打一行，
+// 不像 HTTP 分支那样发 OTel 日志；成功时不打日志。
+func runGRPCIteration(ctx context.Context, span trace.Span, client echopb.EchoServiceClient) {
+	const grpcRoute = "grpc:/Slow"
+
+	start := time.Now()
+	_, err := client.Slow(ctx, &echopb.SlowRequest{Iterations: 50})
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		requestCount.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("method", "GRPC"),
+			attribute.String("status", "error"),
+			attribute.String("route", grpcRoute),
+		))
+		span.RecordError(err)
+		log.Printf("[ERROR] route=%s err=%v", grpcRoute, err)
+		return
+	}
+
+	requestCount.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("method", "GRPC"),
+		attribute.String("status", "ok"),
+		attribute.String("route", grpcRoute),
+	))
+	histogram.Record(ctx, duration, metric.WithAttributes(attribute.String("route", grpcRoute)))
+}
+
+// newEchoClient 创建一个带 otelgrpc 拦截器的 EchoService 客户端，W3C traceparent / baggage
+// 会通过 gRPC metadata 自动传播给下游的 gRPC 服务。
+func newEchoClient() (echopb.EchoServiceClient, func() error, error) {
+	conn, err := grpc.NewClient(
+		grpcAddr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to dial grpc server: %w", err)
+	}
+	return echopb.NewEchoServiceClient(conn), conn.Close, nil
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"sync"
 	"time"
 
 	otelpyroscope "github.com/grafana/otel-profiling-go"
@@ -15,9 +16,6 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	otel_log "go.opentelemetry.io/otel/log"
 	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/metric"
@@ -46,8 +44,11 @@ var (
 	meter  = otel.Meter(serviceName)
 	logger = global.Logger(serviceName)
 
-	// 用于模拟“持续占用内存”的场景（轻量级版内存泄漏 demo）
-	allocHolder [][]byte
+	// 用于模拟“持续占用内存”的场景（轻量级版内存泄漏 demo）。
+	// allocHolder 会被多个并发的 /alloc 请求处理协程写入，statelogger 又从后台协程读取它的
+	// 长度，所以两侧都要经过 allocHolderMu，不能直接裸读/裸写。
+	allocHolderMu sync.Mutex
+	allocHolder   [][]byte
 
 	// 模拟“写得不合理的邮箱校验正则”，用于制造 CPU 压力
 	//
@@ -86,22 +87,38 @@ func initProvider(ctx context.Context) (func(context.Context) error, error) {
 	}
 
 	// === Traces ===
-	traceExporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(otlpEndpoint+"/v1/traces"))
+	// 传输协议（http/protobuf、grpc）由 OTEL_EXPORTER_OTLP_PROTOCOL 决定，
+	// 具体的 exporter 构造逻辑见 transport.go。
+	traceExporter, err := buildTraceExporter(ctx, otlpEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
 	}
-	// 原始 OTel TracerProvider
-	baseTP := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(traceExporter),
+
+	// 头部采样：由 OTEL_TRACES_SAMPLER_ARG 控制采样比例，默认全采，行为与之前一致。
+	headSampler := buildHeadSampler()
+
+	// 尾部采样：默认关闭；开启后（DEMO_TAIL_SAMPLING_ENABLED=true）用一个自定义
+	// SpanProcessor 包裹真正的 batcher，只有满足错误/高延迟/规则匹配之一的 trace
+	// 才会被转发给 OTLP exporter，其余直接丢弃。
+	batcher := sdktrace.NewBatchSpanProcessor(traceExporter)
+	tpOpts := []sdktrace.TracerProviderOption{
 		sdktrace.WithResource(res),
-	)
+		sdktrace.WithSampler(headSampler),
+	}
+	if tailSamplingEnabled() {
+		tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(newTailSamplingProcessor(batcher)))
+	} else {
+		tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(batcher))
+	}
+	// 原始 OTel TracerProvider
+	baseTP := sdktrace.NewTracerProvider(tpOpts...)
 	// 使用 otel-profiling-go 包装 TracerProvider，让 span 自动携带 pyroscope.profile.id 等 Profiling 关联信息
 	tp := otelpyroscope.NewTracerProvider(baseTP)
 	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
 	// === Metrics ===
-	metricExporter, err := otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpointURL(otlpEndpoint+"/v1/metrics"))
+	metricExporter, err := buildMetricExporter(ctx, otlpEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create metric exporter: %w", err)
 	}
@@ -112,7 +129,7 @@ func initProvider(ctx context.Context) (func(context.Context) error, error) {
 	otel.SetMeterProvider(mp)
 
 	// === Logs ===
-	logExporter, err := otlploghttp.New(ctx, otlploghttp.WithEndpointURL(otlpEndpoint+"/v1/logs"))
+	logExporter, err := buildLogExporter(ctx, otlpEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create log exporter: %w", err)
 	}
@@ -173,11 +190,37 @@ func main() {
 	if err := initMetrics(); err != nil {
 		log.Fatalf("failed to init metrics: %v", err)
 	}
+	if err := initSamplingMetrics(); err != nil {
+		log.Fatalf("failed to init sampling metrics: %v", err)
+	}
+	if err := initStateLoggerMetrics(); err != nil {
+		log.Fatalf("failed to init state logger metrics: %v", err)
+	}
+
+	// 3.1 可选下游依赖：Redis（邮箱校验结果缓存）和 GORM/SQLite（/order 接口）
+	if err := initRedis(); err != nil {
+		log.Fatalf("failed to init redis: %v", err)
+	}
+	if err := initGorm(); err != nil {
+		log.Fatalf("failed to init gorm: %v", err)
+	}
+	if err := initResilienceMetrics(); err != nil {
+		log.Fatalf("failed to init resilience metrics: %v", err)
+	}
 
 	// 4. 启动 HTTP Server：提供 /hello 和 /slow 两个测试接口
 	startHTTPServer()
 
-	// 5. 启动流量生成循环：不断调用 /slow，制造 Trace + Profile 数据
+	// 4.1 启动下游 gRPC 服务（第二个"进程"），演示跨服务（流量发生器 -> gRPC）的 Trace 传播
+	startGRPCServer()
+
+	// 4.2 启动网络吞吐量采样器，每秒发布一次 system_network_io_bytes_total
+	startNetMetrics(ctx)
+
+	// 4.3 启动运行时状态记录器：周期性采样 goroutine/heap/GC，越界时打 WARN 日志
+	startStateLogger(ctx)
+
+	// 5. 启动流量生成循环：交替调用 HTTP /slow 和 gRPC Slow，制造 Trace + Profile 数据
 	startTrafficGenerator(ctx)
 }
 
@@ -205,12 +248,16 @@ func initMetrics() error {
 // - /slow：模拟 CPU 慢接口（正则匹配）
 // - /alloc：模拟“内存占用/泄漏”接口（大量分配并缓存在全局切片）
 func startHTTPServer() {
-	// /hello: 快速、轻量级请求
-	http.Handle(routeFast, otelhttp.NewHandler(http.HandlerFunc(helloHandler), "Hello"))
+	// /hello: 快速、轻量级请求。每个路由在 otelhttp 之前先过一层令牌桶限流，
+	// 超过配额的请求直接拿到 429，不会再进入业务逻辑。
+	http.Handle(routeFast, otelhttp.NewHandler(
+		rateLimitMiddleware(routeFast, httpRouteLimiters[routeFast], http.HandlerFunc(helloHandler)), "Hello"))
 	// /slow: 人为制造的“慢接口”，CPU 占用明显，方便在 Traces -> Profiles 里演示
-	http.Handle(routeSlow, otelhttp.NewHandler(http.HandlerFunc(slowHandler), "Slow"))
+	http.Handle(routeSlow, otelhttp.NewHandler(
+		rateLimitMiddleware(routeSlow, httpRouteLimiters[routeSlow], http.HandlerFunc(slowHandler)), "Slow"))
 	// /alloc: 模拟一次请求导致大量内存分配的场景
-	http.Handle(routeAlloc, otelhttp.NewHandler(http.HandlerFunc(allocHandler), "Alloc"))
+	http.Handle(routeAlloc, otelhttp.NewHandler(
+		rateLimitMiddleware(routeAlloc, httpRouteLimiters[routeAlloc], http.HandlerFunc(allocHandler)), "Alloc"))
 
 	go func() {
 		log.Println("HTTP server listening on :8080")
@@ -229,7 +276,15 @@ func startTrafficGenerator(ctx context.Context) {
 	log.Println("Starting traffic generator...")
 	client := http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
 
+	echoClient, closeEchoClient, err := newEchoClient()
+	if err != nil {
+		log.Fatalf("failed to create grpc client: %v", err)
+	}
+	defer closeEchoClient()
+
+	iteration := 0
 	for {
+		iteration++
 		// 为每一次“调用下游服务”创建一个新的顶层 span
 		// 在 Tempo 中你会看到它的名字是 traffic_generator_request
 		iterCtx, span := tracer.Start(ctx, "traffic_generator_request")
@@ -237,22 +292,41 @@ func startTrafficGenerator(ctx context.Context) {
 			attribute.String("job", jobName),
 			attribute.String("service_name", serviceName),
 		)
+		rootTraceID := span.SpanContext().TraceID().String()
+		trackRootTraceID(rootTraceID)
 
 		// 在“流量发生器”这层也做一部分正则匹配，方便在 Profiles 中看到
 		// traffic_generator_request 这个 span 的 CPU 占用。
 		_ = checkEmail()
 
+		// 交替调用 HTTP /slow 和 gRPC Slow。gRPC 调用带着 iterCtx，挂在本轮的
+		// traffic_generator_request span 下面；HTTP /slow 请求由 callSlowWithResilience 发出，
+		// 不带 iterCtx，所以不会挂在这个 span 下面，在 Tempo 里是一条单独的 trace。
+		if iteration%2 == 0 {
+			runGRPCIteration(iterCtx, span, echoClient)
+			span.End()
+			untrackRootTraceID(rootTraceID)
+			time.Sleep(time.Millisecond * time.Duration(100+rand.Intn(500)))
+			continue
+		}
+
 		// Make Request (调用慢接口 /slow，更直观地看到 span 级火焰图)
-		start := time.Now()
-		resp, err := client.Get("http://localhost:8080" + routeSlow)
-		duration := time.Since(start).Seconds()
+		// 这层调用套了限流 + 熔断：被限流/熔断打开时不会真的发请求，而是走降级路径。
+		duration, degraded, err := callSlowWithResilience(&client)
 
 		// Extract trace context for correlation
 		spanCtx := span.SpanContext()
 		traceID := spanCtx.TraceID().String()
 		spanID := spanCtx.SpanID().String()
 
-		if err != nil {
+		if degraded {
+			requestCount.Add(iterCtx, 1, metric.WithAttributes(
+				attribute.String("method", "GET"),
+				attribute.String("status", "degraded"),
+				attribute.String("route", routeSlow),
+			))
+			span.SetAttributes(attribute.Bool("degraded", true))
+		} else if err != nil {
 			// Log Error
 			r := otel_log.Record{}
 			r.SetTimestamp(time.Now())
@@ -278,7 +352,6 @@ func startTrafficGenerator(ctx context.Context) {
 				attribute.String("route", routeSlow),
 			))
 			histogram.Record(iterCtx, duration, metric.WithAttributes(attribute.String("route", routeSlow)))
-			resp.Body.Close()
 
 			// Log Success
 			r := otel_log.Record{}
@@ -301,6 +374,7 @@ func startTrafficGenerator(ctx context.Context) {
 		}
 
 		span.End()
+		untrackRootTraceID(rootTraceID)
 		time.Sleep(time.Millisecond * time.Duration(100+rand.Intn(500)))
 	}
 }
@@ -337,12 +411,14 @@ func allocHandler(w http.ResponseWriter, r *http.Request) {
 // 在 Tempo 中点击这个 span 的 Profiles for this span，可以非常直观地看到火焰图。
 func slowHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	_, span := tracer.Start(ctx, "slow_business_logic")
+	bizCtx, span := tracer.Start(ctx, "slow_business_logic")
 	defer span.End()
 
-	// 模拟耗时的业务逻辑：多次调用高成本的邮箱校验逻辑
+	// 模拟耗时的业务逻辑：多次调用高成本的邮箱校验逻辑。
+	// 启用 Redis 时（DEMO_REDIS_ENABLED=true），第一次之后的结果会走缓存，
+	// 从第二次开始这里基本只剩 Redis 往返耗时。
 	for i := 0; i < 50; i++ {
-		_ = checkEmail()
+		_ = checkEmailCached(bizCtx, slowEmailSample)
 	}
 
 	w.Write([]byte("Slow endpoint finished"))
@@ -382,15 +458,25 @@ func allocateMemoryBurst() {
 		maxRetained = 20         // 最多保留 20 批（约 1GB 上限，实际会被 GC/操作系统回收一部分）
 	)
 
-	// 如果已经保留了很多批数据，就丢弃最早的一批，避免无限增长
-	if len(allocHolder) >= maxRetained {
-		allocHolder = allocHolder[1:]
-	}
-
 	batch := make([]byte, chunkSize*chunkCount)
 	// 简单写入一点数据，避免被编译器优化掉
 	for i := range batch {
 		batch[i] = byte(i)
 	}
+
+	allocHolderMu.Lock()
+	// 如果已经保留了很多批数据，就丢弃最早的一批，避免无限增长
+	if len(allocHolder) >= maxRetained {
+		allocHolder = allocHolder[1:]
+	}
 	allocHolder = append(allocHolder, batch)
+	allocHolderMu.Unlock()
+}
+
+// allocHolderLen 加锁读取 allocHolder 的长度，供 statelogger 之类的后台协程使用，
+// 避免和 allocateMemoryBurst 的并发写入产生数据竞争。
+func allocHolderLen() int {
+	allocHolderMu.Lock()
+	defer allocHolderMu.Unlock()
+	return len(allocHolder)
 }
@@ -0,0 +1,222 @@
+// Package netmetrics 按网卡采样 RX/TX 字节数，通过 OTel 指标暴露出去，并维护一个
+// 60 秒滑动窗口的“最高流量网卡”排行，供 /net/top 接口查询。
+package netmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mackerelio/go-osstat/network"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// direction 标识一条统计记录是接收还是发送方向。
+type direction string
+
+const (
+	directionRX direction = "rx"
+	directionTX direction = "tx"
+)
+
+// InterfaceStats 是单块网卡在某一时刻的累计收发字节数（对应 /proc/net/dev 里的计数器）。
+type InterfaceStats struct {
+	Name    string
+	RxBytes uint64
+	TxBytes uint64
+}
+
+// StatsProvider 抽象了“如何拿到当前所有网卡的计数器快照”，方便在测试中替换为假数据。
+type StatsProvider interface {
+	Sample() ([]InterfaceStats, error)
+}
+
+// osStatsProvider 是基于 github.com/mackerelio/go-osstat/network 的默认实现。
+type osStatsProvider struct{}
+
+func (osStatsProvider) Sample() ([]InterfaceStats, error) {
+	stats, err := network.Get()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]InterfaceStats, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, InterfaceStats{Name: s.Name, RxBytes: s.RxBytes, TxBytes: s.TxBytes})
+	}
+	return out, nil
+}
+
+// sample 是滑动窗口里的一个采样点：某个时刻、某块网卡在这一采样周期内的增量字节数。
+type sample struct {
+	at      time.Time
+	iface   string
+	rxDelta uint64
+	txDelta uint64
+}
+
+// Sampler 每秒采样一次所有网卡的计数器，把“增量”发布为 OTel 指标，并维护最近 60 秒的
+// 滑动窗口用于 /net/top 排行。
+type Sampler struct {
+	provider StatsProvider
+	interval time.Duration
+	window   time.Duration
+
+	// counter 是 system_network_io_bytes_total，rx/tx 两个方向共用同一个 Instrument，
+	// 通过 direction 属性区分，这是 OTel 里处理"同一指标、不同维度"的通常做法。
+	counter metric.Int64Counter
+
+	mu      sync.Mutex
+	last    map[string]InterfaceStats
+	samples []sample
+}
+
+// NewSampler 构造一个 Sampler。interval 控制采样频率（默认建议 1s），window 控制
+// /net/top 排行的统计窗口（默认建议 60s）。
+func NewSampler(meter metric.Meter, interval, window time.Duration) (*Sampler, error) {
+	counter, err := meter.Int64Counter(
+		"system_network_io_bytes_total",
+		metric.WithDescription("Cumulative bytes observed per network interface"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sampler{
+		provider: osStatsProvider{},
+		interval: interval,
+		window:   window,
+		counter:  counter,
+		last:     make(map[string]InterfaceStats),
+	}, nil
+}
+
+// newSamplerForTest 允许测试注入一个假的 StatsProvider，绕开真实的 meter 创建（用 noop 也可以）。
+func newSamplerForTest(provider StatsProvider, counter metric.Int64Counter, interval, window time.Duration) *Sampler {
+	return &Sampler{
+		provider: provider,
+		interval: interval,
+		window:   window,
+		counter:  counter,
+		last:     make(map[string]InterfaceStats),
+	}
+}
+
+// Run 阻塞式地按 interval 周期采样，直到 ctx 被取消。通常以 goroutine 形式从 main 启动。
+func (s *Sampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.tick(ctx, now)
+		}
+	}
+}
+
+// tick 采样一次、计算相对上一次的增量、上报指标并推入滑动窗口，然后清理过期的采样点。
+func (s *Sampler) tick(ctx context.Context, now time.Time) {
+	stats, err := s.provider.Sample()
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	for _, st := range stats {
+		prev, ok := s.last[st.Name]
+		s.last[st.Name] = st
+		if !ok {
+			// 第一次看到这块网卡，没有基准值可以算增量，跳过本轮。
+			continue
+		}
+
+		rxDelta := diffCounter(prev.RxBytes, st.RxBytes)
+		txDelta := diffCounter(prev.TxBytes, st.TxBytes)
+
+		// 本周期没有任何收发的网卡不进入滑动窗口，因此窗口内没有流量的网卡不会出现在 Top 里。
+		if rxDelta > 0 || txDelta > 0 {
+			s.samples = append(s.samples, sample{at: now, iface: st.Name, rxDelta: rxDelta, txDelta: txDelta})
+		}
+
+		s.counter.Add(ctx, int64(rxDelta), metric.WithAttributes(
+			attribute.String("iface", st.Name), attribute.String("direction", string(directionRX))))
+		s.counter.Add(ctx, int64(txDelta), metric.WithAttributes(
+			attribute.String("iface", st.Name), attribute.String("direction", string(directionTX))))
+	}
+
+	cutoff := now.Add(-s.window)
+	kept := s.samples[:0]
+	for _, sm := range s.samples {
+		if sm.at.After(cutoff) {
+			kept = append(kept, sm)
+		}
+	}
+	s.samples = kept
+	s.mu.Unlock()
+}
+
+// diffCounter 计算单调递增计数器的增量，处理计数器被重置（新值小于旧值）的情况。
+func diffCounter(prev, cur uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// TopEntry 是 /net/top 返回的一条排行记录：某块网卡在窗口内的收发字节数之和。
+type TopEntry struct {
+	Interface string `json:"interface"`
+	RxBytes   uint64 `json:"rx_bytes"`
+	TxBytes   uint64 `json:"tx_bytes"`
+	Total     uint64 `json:"total_bytes"`
+}
+
+// Top 返回窗口内按 (RxBytes+TxBytes) 降序排列的前 n 块网卡。
+func (s *Sampler) Top(n int) []TopEntry {
+	totals := make(map[string]*TopEntry)
+
+	s.mu.Lock()
+	for _, sm := range s.samples {
+		e, ok := totals[sm.iface]
+		if !ok {
+			e = &TopEntry{Interface: sm.iface}
+			totals[sm.iface] = e
+		}
+		e.RxBytes += sm.rxDelta
+		e.TxBytes += sm.txDelta
+	}
+	s.mu.Unlock()
+
+	entries := make([]TopEntry, 0, len(totals))
+	for _, e := range totals {
+		e.Total = e.RxBytes + e.TxBytes
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Total > entries[j].Total })
+
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// TopHandler 返回一个 http.HandlerFunc，把 Top(n) 的结果以 JSON 形式返回；
+// 支持 ?n= 查询参数（默认 5）。
+func (s *Sampler) TopHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := 5
+		if raw := r.URL.Query().Get("n"); raw != "" {
+			if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+				n = parsed
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.Top(n))
+	}
+}
@@ -0,0 +1,126 @@
+package netmetrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// fakeProvider 按调用顺序依次返回预设的快照，用来确定性地驱动 Sampler.tick。
+type fakeProvider struct {
+	snapshots [][]InterfaceStats
+	i         int
+}
+
+func (f *fakeProvider) Sample() ([]InterfaceStats, error) {
+	if f.i >= len(f.snapshots) {
+		return f.snapshots[len(f.snapshots)-1], nil
+	}
+	s := f.snapshots[f.i]
+	f.i++
+	return s, nil
+}
+
+func newTestSampler(t *testing.T, provider StatsProvider, window time.Duration) *Sampler {
+	t.Helper()
+	meter := noop.NewMeterProvider().Meter("netmetrics-test")
+	counter, err := meter.Int64Counter("system_network_io_bytes_total")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	return newSamplerForTest(provider, counter, time.Second, window)
+}
+
+func TestSamplerComputesDeltasAcrossTicks(t *testing.T) {
+	provider := &fakeProvider{
+		snapshots: [][]InterfaceStats{
+			{{Name: "eth0", RxBytes: 1000, TxBytes: 500}},
+			{{Name: "eth0", RxBytes: 1500, TxBytes: 700}},
+		},
+	}
+	s := newTestSampler(t, provider, time.Minute)
+
+	base := time.Unix(0, 0)
+	s.tick(context.Background(), base)                  // 建立基准值，本轮不产生增量
+	s.tick(context.Background(), base.Add(time.Second)) // 产生一次增量
+
+	top := s.Top(5)
+	if len(top) != 1 {
+		t.Fatalf("expected 1 interface in top, got %d", len(top))
+	}
+	if top[0].RxBytes != 500 || top[0].TxBytes != 200 {
+		t.Fatalf("unexpected deltas: rx=%d tx=%d", top[0].RxBytes, top[0].TxBytes)
+	}
+}
+
+func TestSamplerHandlesCounterReset(t *testing.T) {
+	provider := &fakeProvider{
+		snapshots: [][]InterfaceStats{
+			{{Name: "eth0", RxBytes: 1000, TxBytes: 1000}},
+			{{Name: "eth0", RxBytes: 50, TxBytes: 50}}, // 网卡计数器被重置（如系统重启）
+		},
+	}
+	s := newTestSampler(t, provider, time.Minute)
+
+	base := time.Unix(0, 0)
+	s.tick(context.Background(), base)
+	s.tick(context.Background(), base.Add(time.Second))
+
+	top := s.Top(5)
+	if len(top) != 1 {
+		t.Fatalf("expected 1 interface in top, got %d", len(top))
+	}
+	// 计数器被重置时，直接把新值当作本轮增量，而不是算出负数。
+	if top[0].RxBytes != 50 || top[0].TxBytes != 50 {
+		t.Fatalf("unexpected deltas after reset: rx=%d tx=%d", top[0].RxBytes, top[0].TxBytes)
+	}
+}
+
+func TestSamplerExpiresOldSamplesOutsideWindow(t *testing.T) {
+	provider := &fakeProvider{
+		snapshots: [][]InterfaceStats{
+			{{Name: "eth0", RxBytes: 0, TxBytes: 0}},
+			{{Name: "eth0", RxBytes: 1000, TxBytes: 0}},
+			{{Name: "eth0", RxBytes: 1000, TxBytes: 0}}, // 第二次之后没有新增量
+		},
+	}
+	s := newTestSampler(t, provider, 2*time.Second)
+
+	base := time.Unix(0, 0)
+	s.tick(context.Background(), base)
+	s.tick(context.Background(), base.Add(time.Second))
+	s.tick(context.Background(), base.Add(10*time.Second)) // 远超窗口，旧样本应被清理
+
+	top := s.Top(5)
+	if len(top) != 0 {
+		t.Fatalf("expected expired samples to be pruned, got %+v", top)
+	}
+}
+
+func TestTopRespectsLimit(t *testing.T) {
+	provider := &fakeProvider{
+		snapshots: [][]InterfaceStats{
+			{{Name: "eth0"}, {Name: "eth1"}, {Name: "eth2"}},
+			{
+				{Name: "eth0", RxBytes: 100},
+				{Name: "eth1", RxBytes: 300},
+				{Name: "eth2", RxBytes: 200},
+			},
+		},
+	}
+	s := newTestSampler(t, provider, time.Minute)
+
+	base := time.Unix(0, 0)
+	s.tick(context.Background(), base)
+	s.tick(context.Background(), base.Add(time.Second))
+
+	top := s.Top(2)
+	if len(top) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(top))
+	}
+	if top[0].Interface != "eth1" || top[1].Interface != "eth2" {
+		t.Fatalf("unexpected ranking: %+v", top)
+	}
+}
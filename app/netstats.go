@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/grafana/oteldemo/netmetrics"
+)
+
+// netSampleInterval / netTopWindow 控制网络吞吐采样的频率和 /net/top 排行的统计窗口。
+const (
+	netSampleInterval = time.Second
+	netTopWindow      = 60 * time.Second
+)
+
+// startNetMetrics 启动每秒一次的网卡 RX/TX 采样（见 netmetrics 包），并把排行结果
+// 挂载到 /net/top，返回当前窗口内流量最高的 N 块网卡。
+func startNetMetrics(ctx context.Context) {
+	sampler, err := netmetrics.NewSampler(meter, netSampleInterval, netTopWindow)
+	if err != nil {
+		log.Printf("failed to init netmetrics sampler: %v", err)
+		return
+	}
+
+	http.Handle("/net/top", sampler.TopHandler())
+	go sampler.Run(ctx)
+}
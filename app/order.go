@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/plugin/opentelemetry/tracing"
+)
+
+// /order 是一个可选的下游依赖演示：用 GORM + SQLite 做一次简单的下单流程
+// （插入一条订单，再查出最近的几条），让 Tempo 里的调用链多一个 DB 节点，
+// 也给 Pyroscope 的火焰图增加一点 SQL 相关的调用栈。同时注册
+// demo_gorm_query_duration_seconds 直方图（按 op=insert/select 区分），
+// 和 deps.go 里 Redis 的 demo_redis_query_duration_seconds 对称。
+//   - DEMO_GORM_ENABLED：是否启用，默认 false。
+//   - DEMO_SQLITE_PATH：SQLite 数据库文件路径，默认 demo.db。
+const (
+	envGormEnabled = "DEMO_GORM_ENABLED"
+	envSQLitePath  = "DEMO_SQLITE_PATH"
+
+	defaultSQLitePath = "demo.db"
+	routeOrder        = "/order"
+)
+
+var (
+	db *gorm.DB
+
+	ormQueryDuration metric.Float64Histogram
+)
+
+// order 对应 orders 表的一行，字段故意保持简单：这只是一个演示用的下单记录。
+type order struct {
+	ID        uint `gorm:"primarykey"`
+	Item      string
+	Quantity  int
+	CreatedAt time.Time
+}
+
+func gormEnabled() bool {
+	return os.Getenv(envGormEnabled) == "true" || os.Getenv(envGormEnabled) == "1"
+}
+
+// initGorm 在 gormEnabled() 为真时打开 SQLite 数据库、装上 otel tracing 插件、建表，
+// 并把 /order 路由注册到 HTTP server 上。
+func initGorm() error {
+	if !gormEnabled() {
+		return nil
+	}
+
+	path := os.Getenv(envSQLitePath)
+	if path == "" {
+		path = defaultSQLitePath
+	}
+
+	conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to open sqlite db: %w", err)
+	}
+	if err := conn.Use(tracing.NewPlugin()); err != nil {
+		return fmt.Errorf("failed to install gorm otel plugin: %w", err)
+	}
+	if err := conn.AutoMigrate(&order{}); err != nil {
+		return fmt.Errorf("failed to migrate orders table: %w", err)
+	}
+
+	ormQueryDuration, err = meter.Float64Histogram("demo_gorm_query_duration_seconds", metric.WithDescription("GORM/SQLite query duration, labeled by op"))
+	if err != nil {
+		return err
+	}
+
+	db = conn
+	http.Handle(routeOrder, otelhttp.NewHandler(http.HandlerFunc(orderHandler), "Order"))
+	log.Printf("gorm/sqlite enabled, path=%s", path)
+	return nil
+}
+
+// orderHandler 模拟一次下单：插入一条随机的订单记录，然后查询最近 5 条订单返回。
+// gorm 的 tracing 插件会自动在当前 span 下挂出 INSERT / SELECT 两个子 span。
+func orderHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := tracer.Start(ctx, "order_business_logic")
+	defer span.End()
+
+	newOrder := order{Item: fmt.Sprintf("item-%d", rand.Intn(1000)), Quantity: 1 + rand.Intn(5)}
+	start := time.Now()
+	err := db.WithContext(ctx).Create(&newOrder).Error
+	ormQueryDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attribute.String("op", "insert")))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to create order: %v", err), http.StatusInternalServerError)
+		span.RecordError(err)
+		return
+	}
+
+	var recent []order
+	start = time.Now()
+	err = db.WithContext(ctx).Order("id desc").Limit(5).Find(&recent).Error
+	ormQueryDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attribute.String("op", "select")))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to list orders: %v", err), http.StatusInternalServerError)
+		span.RecordError(err)
+		return
+	}
+
+	w.Write([]byte(fmt.Sprintf("created order #%d, %d recent orders on file", newOrder.ID, len(recent))))
+}
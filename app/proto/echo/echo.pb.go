@@ -0,0 +1,158 @@
+// Hand-written stand-in for the protoc-gen-go output of echo.proto — not produced by protoc.
+// `ProtoReflect` below only works because it falls through to protobuf-go's "legacy aberrant
+// message" reflection path, which builds a descriptor from the struct tags at runtime; there is
+// no file descriptor, message-type array, or protoc/protoc-gen-go version stamp here the way real
+// generated output would have. Run `make proto` (see buf.gen.yaml in this directory) once protoc
+// and protoc-gen-go are available and check in the real output in its place.
+// source: echo.proto
+
+package echo
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+)
+
+type FastRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *FastRequest) Reset()                             { *x = FastRequest{} }
+func (x *FastRequest) String() string                     { return protoimpl.X.MessageStringOf(x) }
+func (*FastRequest) ProtoMessage()                        {}
+func (x *FastRequest) ProtoReflect() protoreflect.Message { return protoimpl.X.MessageOf(x) }
+
+func (x *FastRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type FastReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *FastReply) Reset()                             { *x = FastReply{} }
+func (x *FastReply) String() string                     { return protoimpl.X.MessageStringOf(x) }
+func (*FastReply) ProtoMessage()                        {}
+func (x *FastReply) ProtoReflect() protoreflect.Message { return protoimpl.X.MessageOf(x) }
+
+func (x *FastReply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type SlowRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Iterations int32 `protobuf:"varint,1,opt,name=iterations,proto3" json:"iterations,omitempty"`
+}
+
+func (x *SlowRequest) Reset()                             { *x = SlowRequest{} }
+func (x *SlowRequest) String() string                     { return protoimpl.X.MessageStringOf(x) }
+func (*SlowRequest) ProtoMessage()                        {}
+func (x *SlowRequest) ProtoReflect() protoreflect.Message { return protoimpl.X.MessageOf(x) }
+
+func (x *SlowRequest) GetIterations() int32 {
+	if x != nil {
+		return x.Iterations
+	}
+	return 0
+}
+
+type SlowReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Message    string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	DurationMs int64  `protobuf:"varint,2,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+}
+
+func (x *SlowReply) Reset()                             { *x = SlowReply{} }
+func (x *SlowReply) String() string                     { return protoimpl.X.MessageStringOf(x) }
+func (*SlowReply) ProtoMessage()                        {}
+func (x *SlowReply) ProtoReflect() protoreflect.Message { return protoimpl.X.MessageOf(x) }
+
+func (x *SlowReply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *SlowReply) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+type AllocRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ChunkKb    int32 `protobuf:"varint,1,opt,name=chunk_kb,json=chunkKb,proto3" json:"chunk_kb,omitempty"`
+	ChunkCount int32 `protobuf:"varint,2,opt,name=chunk_count,json=chunkCount,proto3" json:"chunk_count,omitempty"`
+}
+
+func (x *AllocRequest) Reset()                             { *x = AllocRequest{} }
+func (x *AllocRequest) String() string                     { return protoimpl.X.MessageStringOf(x) }
+func (*AllocRequest) ProtoMessage()                        {}
+func (x *AllocRequest) ProtoReflect() protoreflect.Message { return protoimpl.X.MessageOf(x) }
+
+func (x *AllocRequest) GetChunkKb() int32 {
+	if x != nil {
+		return x.ChunkKb
+	}
+	return 0
+}
+
+func (x *AllocRequest) GetChunkCount() int32 {
+	if x != nil {
+		return x.ChunkCount
+	}
+	return 0
+}
+
+type AllocReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Message        string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	BytesAllocated int64  `protobuf:"varint,2,opt,name=bytes_allocated,json=bytesAllocated,proto3" json:"bytes_allocated,omitempty"`
+}
+
+func (x *AllocReply) Reset()                             { *x = AllocReply{} }
+func (x *AllocReply) String() string                     { return protoimpl.X.MessageStringOf(x) }
+func (*AllocReply) ProtoMessage()                        {}
+func (x *AllocReply) ProtoReflect() protoreflect.Message { return protoimpl.X.MessageOf(x) }
+
+func (x *AllocReply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AllocReply) GetBytesAllocated() int64 {
+	if x != nil {
+		return x.BytesAllocated
+	}
+	return 0
+}
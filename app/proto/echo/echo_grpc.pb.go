@@ -0,0 +1,147 @@
+// Hand-written stand-in for the protoc-gen-go-grpc output of echo.proto — not produced by
+// protoc-gen-go-grpc. Run `make proto` (see buf.gen.yaml in this directory) once protoc and
+// protoc-gen-go-grpc are available and check in the real generated output in its place.
+// source: echo.proto
+
+package echo
+
+import (
+	context "context"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+const (
+	EchoService_Fast_FullMethodName  = "/echo.EchoService/Fast"
+	EchoService_Slow_FullMethodName  = "/echo.EchoService/Slow"
+	EchoService_Alloc_FullMethodName = "/echo.EchoService/Alloc"
+)
+
+// EchoServiceClient is the client API for EchoService service.
+type EchoServiceClient interface {
+	Fast(ctx context.Context, in *FastRequest, opts ...grpc.CallOption) (*FastReply, error)
+	Slow(ctx context.Context, in *SlowRequest, opts ...grpc.CallOption) (*SlowReply, error)
+	Alloc(ctx context.Context, in *AllocRequest, opts ...grpc.CallOption) (*AllocReply, error)
+}
+
+type echoServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEchoServiceClient(cc grpc.ClientConnInterface) EchoServiceClient {
+	return &echoServiceClient{cc}
+}
+
+func (c *echoServiceClient) Fast(ctx context.Context, in *FastRequest, opts ...grpc.CallOption) (*FastReply, error) {
+	out := new(FastReply)
+	err := c.cc.Invoke(ctx, EchoService_Fast_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *echoServiceClient) Slow(ctx context.Context, in *SlowRequest, opts ...grpc.CallOption) (*SlowReply, error) {
+	out := new(SlowReply)
+	err := c.cc.Invoke(ctx, EchoService_Slow_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *echoServiceClient) Alloc(ctx context.Context, in *AllocRequest, opts ...grpc.CallOption) (*AllocReply, error) {
+	out := new(AllocReply)
+	err := c.cc.Invoke(ctx, EchoService_Alloc_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EchoServiceServer is the server API for EchoService service.
+// All implementations must embed UnimplementedEchoServiceServer for forward compatibility.
+type EchoServiceServer interface {
+	Fast(context.Context, *FastRequest) (*FastReply, error)
+	Slow(context.Context, *SlowRequest) (*SlowReply, error)
+	Alloc(context.Context, *AllocRequest) (*AllocReply, error)
+	mustEmbedUnimplementedEchoServiceServer()
+}
+
+// UnimplementedEchoServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedEchoServiceServer struct{}
+
+func (UnimplementedEchoServiceServer) Fast(context.Context, *FastRequest) (*FastReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Fast not implemented")
+}
+func (UnimplementedEchoServiceServer) Slow(context.Context, *SlowRequest) (*SlowReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Slow not implemented")
+}
+func (UnimplementedEchoServiceServer) Alloc(context.Context, *AllocRequest) (*AllocReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Alloc not implemented")
+}
+func (UnimplementedEchoServiceServer) mustEmbedUnimplementedEchoServiceServer() {}
+
+func RegisterEchoServiceServer(s grpc.ServiceRegistrar, srv EchoServiceServer) {
+	s.RegisterService(&EchoService_ServiceDesc, srv)
+}
+
+func _EchoService_Fast_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FastRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EchoServiceServer).Fast(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: EchoService_Fast_FullMethodName}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EchoServiceServer).Fast(ctx, req.(*FastRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EchoService_Slow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SlowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EchoServiceServer).Slow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: EchoService_Slow_FullMethodName}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EchoServiceServer).Slow(ctx, req.(*SlowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EchoService_Alloc_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AllocRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EchoServiceServer).Alloc(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: EchoService_Alloc_FullMethodName}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EchoServiceServer).Alloc(ctx, req.(*AllocRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// EchoService_ServiceDesc is the grpc.ServiceDesc for EchoService service.
+var EchoService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "echo.EchoService",
+	HandlerType: (*EchoServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Fast", Handler: _EchoService_Fast_Handler},
+		{MethodName: "Slow", Handler: _EchoService_Slow_Handler},
+		{MethodName: "Alloc", Handler: _EchoService_Alloc_Handler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "echo.proto",
+}
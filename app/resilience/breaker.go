@@ -0,0 +1,181 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState 是熔断器的三种状态：关闭（放行）、打开（拒绝）、半开（探测性放行）。
+type BreakerState int
+
+const (
+	StateClosed BreakerState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// outcome 是滚动窗口里按时间戳记录的一次调用结果，用来计算失败率。
+type outcome struct {
+	at      time.Time
+	success bool
+}
+
+// Breaker 是一个简单的失败率熔断器：
+//   - closed：正常放行，统计滚动窗口内的失败率；
+//   - 当样本数达到 minRequests 且失败率达到或超过 failureRatio 时，转为 open；
+//   - open：直接拒绝所有请求，持续 openTimeout 后转为 half_open；
+//   - half_open：放行下一次请求做探测，成功则回到 closed，失败则退回 open。
+type Breaker struct {
+	name         string
+	failureRatio float64
+	window       time.Duration
+	minRequests  int
+	openTimeout  time.Duration
+	onTransition func(from, to BreakerState)
+
+	mu               sync.Mutex
+	state            BreakerState
+	openedAt         time.Time
+	history          []outcome
+	halfOpenInFlight bool
+}
+
+// NewBreaker 构造一个熔断器。name 只是用来在日志/指标里标识它；failureRatio 是
+// 0~1 的失败率阈值；window 是滚动窗口时长；minRequests 是窗口内触发判断所需的最少样本数；
+// openTimeout 是 open 状态维持多久后尝试半开。
+func NewBreaker(name string, failureRatio float64, window time.Duration, minRequests int, openTimeout time.Duration) *Breaker {
+	return &Breaker{
+		name:         name,
+		failureRatio: failureRatio,
+		window:       window,
+		minRequests:  minRequests,
+		openTimeout:  openTimeout,
+		state:        StateClosed,
+	}
+}
+
+// OnTransition 注册一个回调，熔断器每次状态变化都会调用它（例如用来打日志/上报指标）。
+func (b *Breaker) OnTransition(fn func(from, to BreakerState)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onTransition = fn
+}
+
+// Allow 判断当前是否允许发起一次调用：
+//   - closed：总是允许；
+//   - open：未到 openTimeout 则拒绝，到了则转入 half_open 并放行这一次（探测请求）；
+//   - half_open：只放行一个“在途”的探测请求，其它并发请求直接拒绝。
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateClosed:
+		return true
+	case StateOpen:
+		if time.Since(b.openedAt) >= b.openTimeout {
+			b.setStateLocked(StateHalfOpen)
+			b.halfOpenInFlight = true
+			return true
+		}
+		return false
+	case StateHalfOpen:
+		if b.halfOpenInFlight {
+			return false
+		}
+		b.halfOpenInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordResult 汇报一次被 Allow() 放行的调用的结果，用来驱动状态机转换。
+func (b *Breaker) RecordResult(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+
+	switch b.state {
+	case StateHalfOpen:
+		b.halfOpenInFlight = false
+		if success {
+			b.history = nil
+			b.setStateLocked(StateClosed)
+		} else {
+			b.setStateLocked(StateOpen)
+		}
+		return
+	case StateOpen:
+		// 正常不会走到这里（Allow 已经拒绝），忽略。
+		return
+	}
+
+	b.history = append(b.history, outcome{at: now, success: success})
+	b.pruneLocked(now)
+
+	if len(b.history) < b.minRequests {
+		return
+	}
+
+	failures := 0
+	for _, o := range b.history {
+		if !o.success {
+			failures++
+		}
+	}
+	if float64(failures)/float64(len(b.history)) >= b.failureRatio {
+		b.setStateLocked(StateOpen)
+	}
+}
+
+// pruneLocked 丢弃滚动窗口之外的历史记录，调用方需要持有 mu。
+func (b *Breaker) pruneLocked(now time.Time) {
+	cutoff := now.Add(-b.window)
+	i := 0
+	for ; i < len(b.history); i++ {
+		if b.history[i].at.After(cutoff) {
+			break
+		}
+	}
+	b.history = b.history[i:]
+}
+
+// setStateLocked 切换状态并触发 onTransition 回调，调用方需要持有 mu。
+func (b *Breaker) setStateLocked(to BreakerState) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+	if to == StateOpen {
+		b.openedAt = time.Now()
+	}
+	if b.onTransition != nil {
+		b.onTransition(from, to)
+	}
+}
+
+// State 返回熔断器当前的状态，主要用于上报 demo_circuit_state 指标。
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Name 返回构造时传入的名字。
+func (b *Breaker) Name() string {
+	return b.name
+}
@@ -0,0 +1,31 @@
+package resilience
+
+import "errors"
+
+// ErrRateLimited 和 ErrCircuitOpen 是 Do 在请求被限流/熔断拒绝时返回的哨兵错误，
+// 调用方可以用 errors.Is 判断具体原因，再决定走哪种降级逻辑。
+var (
+	ErrRateLimited = errors.New("resilience: rate limited")
+	ErrCircuitOpen = errors.New("resilience: circuit open")
+)
+
+// Do 用限流器 + 熔断器保护一次调用 fn：
+//   - 先检查限流器，被限流直接返回 ErrRateLimited，不消耗熔断器的配额；
+//   - 再检查熔断器，处于 open（或 half_open 且已有探测在途）时返回 ErrCircuitOpen；
+//   - 否则真正执行 fn，并把结果（是否出错）汇报给熔断器。
+//
+// limiter 和 breaker 都可以传 nil，表示不启用对应的保护。
+func Do(limiter *RateLimiter, breaker *Breaker, fn func() error) error {
+	if limiter != nil && !limiter.Allow() {
+		return ErrRateLimited
+	}
+	if breaker != nil && !breaker.Allow() {
+		return ErrCircuitOpen
+	}
+
+	err := fn()
+	if breaker != nil {
+		breaker.RecordResult(err == nil)
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+// Package resilience 提供一组可复用的“限流 + 熔断 + 降级”中间件，
+// 用来给 demo 里对外/对下游的调用加上基本的弹性保护。
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter 是一个简单的令牌桶限流器：每秒固定补充 qps 个令牌，桶容量为 burst。
+type RateLimiter struct {
+	mu         sync.Mutex
+	qps        float64
+	burst      float64
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewRateLimiter 构造一个令牌桶限流器，qps 是每秒允许的请求数，burst 是桶的最大容量
+// （允许短时间内的突发流量）。
+func NewRateLimiter(qps float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		qps:        qps,
+		burst:      float64(burst),
+		tokens:     float64(burst),
+		lastRefill: time.Now(),
+	}
+}
+
+// Allow 尝试取走一个令牌，返回是否成功；失败意味着本次请求应当被限流丢弃。
+func (l *RateLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(l.lastRefill).Seconds()
+	l.lastRefill = now
+
+	l.tokens += elapsed * l.qps
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
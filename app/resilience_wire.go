@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otel_log "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/oteldemo/resilience"
+)
+
+// 给 /slow 的出站调用（startTrafficGenerator -> HTTP /slow）加的限流 + 熔断配置。
+// QPS/突发值、失败率阈值都偏保守，目的是在 demo 里能比较容易地触发状态切换做演示。
+const (
+	slowRouteQPS       = 20.0
+	slowRouteBurst     = 10
+	breakerFailureRate = 0.5
+	breakerWindow      = 10 * time.Second
+	breakerMinRequests = 5
+	breakerOpenTimeout = 15 * time.Second
+)
+
+var (
+	slowCallLimiter = resilience.NewRateLimiter(slowRouteQPS, slowRouteBurst)
+	slowCallBreaker = resilience.NewBreaker("downstream_slow", breakerFailureRate, breakerWindow, breakerMinRequests, breakerOpenTimeout)
+
+	httpRouteLimiters = map[string]*resilience.RateLimiter{
+		routeFast:  resilience.NewRateLimiter(50, 20),
+		routeSlow:  resilience.NewRateLimiter(20, 10),
+		routeAlloc: resilience.NewRateLimiter(10, 5),
+	}
+
+	circuitStateGauge    metric.Int64Gauge
+	ratelimitDropCounter metric.Int64Counter
+)
+
+// initResilienceMetrics 注册 demo_circuit_state 和 demo_ratelimit_dropped_total，
+// 并把熔断器的状态切换同时打成一条日志和一次 gauge 上报。两者都用 context.Background()
+// 发出，日志不带 trace 上下文。
+func initResilienceMetrics() error {
+	var err error
+	circuitStateGauge, err = meter.Int64Gauge(
+		"demo_circuit_state",
+		metric.WithDescription("Circuit breaker state: 0=closed 1=open 2=half_open"),
+	)
+	if err != nil {
+		return err
+	}
+	ratelimitDropCounter, err = meter.Int64Counter(
+		"demo_ratelimit_dropped_total",
+		metric.WithDescription("Requests dropped by the rate limiter or rejected by an open circuit breaker, labeled by reason"),
+	)
+	if err != nil {
+		return err
+	}
+
+	slowCallBreaker.OnTransition(func(from, to resilience.BreakerState) {
+		logBreakerTransition(slowCallBreaker.Name(), from, to)
+		circuitStateGauge.Record(context.Background(), int64(to),
+			metric.WithAttributes(attribute.String("breaker", slowCallBreaker.Name())))
+	})
+	return nil
+}
+
+// logBreakerTransition 用和本文件其它日志一致的方式记一条 OTel 日志，说明熔断器状态发生了切换。
+func logBreakerTransition(name string, from, to resilience.BreakerState) {
+	r := otel_log.Record{}
+	r.SetTimestamp(time.Now())
+	r.SetSeverity(otel_log.SeverityWarn)
+	r.SetSeverityText("WARN")
+	r.SetBody(otel_log.StringValue("circuit breaker state transition"))
+	r.AddAttributes(
+		otel_log.String("breaker", name),
+		otel_log.String("from", from.String()),
+		otel_log.String("to", to.String()),
+	)
+	logger.Emit(context.Background(), r)
+}
+
+// rateLimitMiddleware 给一个 http.Handler 套上限流：被限流时记一次 demo_ratelimit_dropped_total
+// 并直接返回 429，不再进入后面的业务逻辑。
+// 这里同时把 route 写成 span 的 http.route 属性——otelhttp.NewHandler 本身不会打这个标签
+// （没有用 WithRouteTag），而尾部采样的路由规则（DEMO_TAIL_SAMPLING_ROUTE_RULE）正是靠它匹配的。
+func rateLimitMiddleware(route string, limiter *resilience.RateLimiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("http.route", route))
+		if !limiter.Allow() {
+			ratelimitDropCounter.Add(r.Context(), 1, metric.WithAttributes(
+				attribute.String("route", route), attribute.String("reason", "rate_limited")))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// callSlowWithResilience 用限流器 + 熔断器包一层 client.Get(routeSlow)。熔断打开或被限流时，
+// 不再真的发请求，而是直接返回一个合成的降级响应，调用方可以像正常响应一样处理耗时和状态。
+func callSlowWithResilience(client *http.Client) (duration float64, degraded bool, err error) {
+	start := time.Now()
+	callErr := resilience.Do(slowCallLimiter, slowCallBreaker, func() error {
+		resp, getErr := client.Get("http://localhost:8080" + routeSlow)
+		if getErr != nil {
+			return getErr
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("downstream returned %s", resp.Status)
+		}
+		return nil
+	})
+	duration = time.Since(start).Seconds()
+
+	switch {
+	case callErr == nil:
+		return duration, false, nil
+	case errors.Is(callErr, resilience.ErrRateLimited):
+		ratelimitDropCounter.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("route", routeSlow), attribute.String("reason", "rate_limited")))
+		// 降级：跳过真实调用，返回 degraded=true、err=nil 的默认结果，而不是让上游也跟着失败。
+		return duration, true, nil
+	case errors.Is(callErr, resilience.ErrCircuitOpen):
+		ratelimitDropCounter.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("route", routeSlow), attribute.String("reason", "circuit_open")))
+		return duration, true, nil
+	default:
+		return duration, false, callErr
+	}
+}
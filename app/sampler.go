@@ -0,0 +1,335 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 采样相关的环境变量：
+//   - OTEL_TRACES_SAMPLER_ARG：头部采样（head sampling）比例，0~1，默认 1（全采）。
+//   - DEMO_TAIL_SAMPLING_ENABLED：是否启用尾部采样（tail-based sampling），默认 false。
+//   - DEMO_TAIL_SAMPLING_WINDOW：每个 trace 在缓冲区中最多等待多久（超时后按已收集的 span 做决策）。
+//   - DEMO_TAIL_SAMPLING_MAX_TRACES：缓冲区最多保留多少个 trace，超出后丢弃最老的。
+//   - DEMO_TAIL_SAMPLING_LATENCY_THRESHOLD_MS：trace 总耗时超过该阈值则保留。
+const (
+	envHeadSamplerRatio       = "OTEL_TRACES_SAMPLER_ARG"
+	envTailSamplingEnabled    = "DEMO_TAIL_SAMPLING_ENABLED"
+	envTailSamplingWindow     = "DEMO_TAIL_SAMPLING_WINDOW"
+	envTailSamplingMaxTraces  = "DEMO_TAIL_SAMPLING_MAX_TRACES"
+	envTailSamplingLatencyMs  = "DEMO_TAIL_SAMPLING_LATENCY_THRESHOLD_MS"
+	envTailSamplingRouteRule  = "DEMO_TAIL_SAMPLING_ROUTE_RULE"            // 形如 "/slow"
+	envTailSamplingRouteMinMs = "DEMO_TAIL_SAMPLING_ROUTE_MIN_DURATION_MS" // 配合上面的 route 规则
+
+	defaultTailSamplingWindow    = 5 * time.Second
+	defaultTailSamplingMaxTraces = 2048
+	defaultTailSamplingLatencyMs = 500
+)
+
+// sampledDecisionCounter 记录尾部采样的决策结果，方便在 Mimir 里验证采样行为是否符合预期。
+var sampledDecisionCounter metric.Int64Counter
+
+// initSamplingMetrics 注册尾部采样相关的指标，需要在 meter 初始化之后调用。
+func initSamplingMetrics() error {
+	var err error
+	sampledDecisionCounter, err = meter.Int64Counter(
+		"demo_traces_sampled_total",
+		metric.WithDescription("Tail-sampling decisions, labeled by decision outcome"),
+	)
+	return err
+}
+
+// buildHeadSampler 根据 OTEL_TRACES_SAMPLER_ARG 构造一个基于比例的头部采样器。
+// 未设置或解析失败时退化为全采样（ratio=1），和原先“always-on”的行为保持一致。
+func buildHeadSampler() sdktrace.Sampler {
+	ratio := 1.0
+	if raw := os.Getenv(envHeadSamplerRatio); raw != "" {
+		if parsed, err := strconv.ParseFloat(raw, 64); err == nil && parsed >= 0 && parsed <= 1 {
+			ratio = parsed
+		} else {
+			log.Printf("invalid %s=%q, falling back to ratio=1", envHeadSamplerRatio, raw)
+		}
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+// tailSamplingRule 描述一条“路由 + 最小耗时”的尾部采样规则，例如 route=/slow 且 duration_ms>500。
+type tailSamplingRule struct {
+	route     string
+	minMillis int64
+}
+
+// traceBuffer 保存某个 trace 已经结束的 span，直到做出采样决策。
+type traceBuffer struct {
+	spans      []sdktrace.ReadOnlySpan
+	hasError   bool
+	start, end time.Time
+	routes     map[string]int64 // route -> 该 route 下观察到的最大耗时（毫秒）
+}
+
+// tailSamplingProcessor 是一个自定义的 sdktrace.SpanProcessor：
+//   - 按 trace ID 缓冲已结束的 span，等待同一 trace 的其它 span 或窗口超时；
+//   - 仅当 trace 命中下列任一条件时，才把整条 trace 转发给下游 exporter：
+//     含错误 span / 总耗时超过阈值 / 命中路由规则（如 route=/slow 且 duration_ms>500）；
+//   - 缓冲区有上限，满了之后丢弃最老的 trace（FIFO），避免无限增长。
+type tailSamplingProcessor struct {
+	next sdktrace.SpanProcessor
+
+	mu         sync.Mutex
+	buffers    map[trace.TraceID]*traceBuffer
+	order      *list.List // 维护 trace 到达顺序，便于淘汰最老的
+	orderIndex map[trace.TraceID]*list.Element
+
+	window          time.Duration
+	maxTraces       int
+	latencyThreshMs int64
+	rule            *tailSamplingRule
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// newTailSamplingProcessor 从环境变量读取配置并构造一个尾部采样处理器，next 是真正把 span
+// 发给 OTLP exporter 的下游处理器（通常是 sdktrace.NewBatchSpanProcessor 包装的 exporter）。
+func newTailSamplingProcessor(next sdktrace.SpanProcessor) *tailSamplingProcessor {
+	window := defaultTailSamplingWindow
+	if raw := os.Getenv(envTailSamplingWindow); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			window = d
+		}
+	}
+
+	maxTraces := defaultTailSamplingMaxTraces
+	if raw := os.Getenv(envTailSamplingMaxTraces); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			maxTraces = n
+		}
+	}
+
+	latencyThreshMs := int64(defaultTailSamplingLatencyMs)
+	if raw := os.Getenv(envTailSamplingLatencyMs); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n > 0 {
+			latencyThreshMs = n
+		}
+	}
+
+	var rule *tailSamplingRule
+	if route := os.Getenv(envTailSamplingRouteRule); route != "" {
+		minMs := int64(500)
+		if raw := os.Getenv(envTailSamplingRouteMinMs); raw != "" {
+			if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n > 0 {
+				minMs = n
+			}
+		}
+		rule = &tailSamplingRule{route: route, minMillis: minMs}
+	}
+
+	p := &tailSamplingProcessor{
+		next:            next,
+		buffers:         make(map[trace.TraceID]*traceBuffer),
+		order:           list.New(),
+		orderIndex:      make(map[trace.TraceID]*list.Element),
+		window:          window,
+		maxTraces:       maxTraces,
+		latencyThreshMs: latencyThreshMs,
+		rule:            rule,
+		stopCh:          make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.flushLoop()
+	return p
+}
+
+// OnStart 对尾部采样来说不需要在 span 开始时做任何事情，决策只在 OnEnd 后根据完整信息做出。
+func (p *tailSamplingProcessor) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {}
+
+// OnEnd 把已结束的 span 放入对应 trace 的缓冲区；如果该 trace 是新的，还会记录到期顺序，
+// 并在缓冲区超过上限时淘汰最老的 trace（对应的 span 直接丢弃，不转发）。
+func (p *tailSamplingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	traceID := s.SpanContext().TraceID()
+	if !traceID.IsValid() {
+		return
+	}
+
+	p.mu.Lock()
+	buf, ok := p.buffers[traceID]
+	if !ok {
+		buf = &traceBuffer{start: s.StartTime(), routes: make(map[string]int64)}
+		p.buffers[traceID] = buf
+		elem := p.order.PushBack(traceID)
+		p.orderIndex[traceID] = elem
+		p.evictLocked()
+	}
+
+	buf.spans = append(buf.spans, s)
+	if s.StartTime().Before(buf.start) {
+		buf.start = s.StartTime()
+	}
+	if s.EndTime().After(buf.end) {
+		buf.end = s.EndTime()
+	}
+	if s.Status().Code == codes.Error {
+		buf.hasError = true
+	}
+	for _, attr := range s.Attributes() {
+		if string(attr.Key) == "http.route" || string(attr.Key) == "route" {
+			route := attr.Value.AsString()
+			durationMs := s.EndTime().Sub(s.StartTime()).Milliseconds()
+			if durationMs > buf.routes[route] {
+				buf.routes[route] = durationMs
+			}
+		}
+	}
+	p.mu.Unlock()
+}
+
+// evictLocked 在持有 mu 的情况下，如果缓冲区超过上限就丢弃最老的 trace。调用方需要持锁。
+func (p *tailSamplingProcessor) evictLocked() {
+	for len(p.buffers) > p.maxTraces {
+		oldest := p.order.Front()
+		if oldest == nil {
+			return
+		}
+		traceID := oldest.Value.(trace.TraceID)
+		p.order.Remove(oldest)
+		delete(p.orderIndex, traceID)
+		delete(p.buffers, traceID)
+		p.recordDecision(context.Background(), "dropped_overflow")
+	}
+}
+
+// flushLoop 周期性扫描缓冲区，把最早的 span 开始时间距今已达到 window 的 trace 做出最终决策并清理。
+func (p *tailSamplingProcessor) flushLoop() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.window / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.sweep()
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// sweep 遍历所有缓冲的 trace，对已经超过 window 的做出决策并转发或丢弃。
+func (p *tailSamplingProcessor) sweep() {
+	now := time.Now()
+	var toDecide []trace.TraceID
+
+	p.mu.Lock()
+	for traceID, buf := range p.buffers {
+		if now.Sub(buf.start) >= p.window {
+			toDecide = append(toDecide, traceID)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, traceID := range toDecide {
+		p.decide(traceID)
+	}
+}
+
+// decide 对单个 trace 做出“保留/丢弃”的最终判断，并把结果转发给下游（或丢弃）。
+func (p *tailSamplingProcessor) decide(traceID trace.TraceID) {
+	p.mu.Lock()
+	buf, ok := p.buffers[traceID]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+	delete(p.buffers, traceID)
+	if elem, ok := p.orderIndex[traceID]; ok {
+		p.order.Remove(elem)
+		delete(p.orderIndex, traceID)
+	}
+	p.mu.Unlock()
+
+	decision, keep := p.evaluate(buf)
+	p.recordDecision(context.Background(), decision)
+	if !keep {
+		return
+	}
+	for _, s := range buf.spans {
+		p.next.OnEnd(s)
+	}
+}
+
+// evaluate 根据“含错误 span / 总耗时超阈值 / 命中路由规则”三个条件判断一个 trace 是否应当被保留。
+func (p *tailSamplingProcessor) evaluate(buf *traceBuffer) (decision string, keep bool) {
+	if buf.hasError {
+		return "error", true
+	}
+	if buf.end.Sub(buf.start).Milliseconds() > p.latencyThreshMs {
+		return "latency", true
+	}
+	if p.rule != nil {
+		if ms, ok := buf.routes[p.rule.route]; ok && ms > p.rule.minMillis {
+			return "rule_match", true
+		}
+	}
+	return "not_sampled", false
+}
+
+// recordDecision 把本次尾部采样的结果记一笔 demo_traces_sampled_total{decision=...} 指标。
+func (p *tailSamplingProcessor) recordDecision(ctx context.Context, decision string) {
+	if sampledDecisionCounter == nil {
+		return
+	}
+	sampledDecisionCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("decision", decision)))
+}
+
+// Shutdown 停止后台扫描协程，并把缓冲区里剩余的所有 trace 立即做决策（flush on shutdown），
+// 最后把请求转发给下游 processor 的 Shutdown。
+func (p *tailSamplingProcessor) Shutdown(ctx context.Context) error {
+	close(p.stopCh)
+	p.wg.Wait()
+
+	p.mu.Lock()
+	var remaining []trace.TraceID
+	for traceID := range p.buffers {
+		remaining = append(remaining, traceID)
+	}
+	p.mu.Unlock()
+
+	for _, traceID := range remaining {
+		p.decide(traceID)
+	}
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush 立即对缓冲区中的所有 trace 做出决策并转发，然后透传给下游。
+func (p *tailSamplingProcessor) ForceFlush(ctx context.Context) error {
+	p.mu.Lock()
+	var all []trace.TraceID
+	for traceID := range p.buffers {
+		all = append(all, traceID)
+	}
+	p.mu.Unlock()
+
+	for _, traceID := range all {
+		p.decide(traceID)
+	}
+	return p.next.ForceFlush(ctx)
+}
+
+var _ sdktrace.SpanProcessor = (*tailSamplingProcessor)(nil)
+
+// tailSamplingEnabled 返回是否应该启用尾部采样处理器（DEMO_TAIL_SAMPLING_ENABLED=true/1）。
+func tailSamplingEnabled() bool {
+	raw := os.Getenv(envTailSamplingEnabled)
+	enabled, _ := strconv.ParseBool(raw)
+	return enabled
+}
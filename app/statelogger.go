@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/pyroscope-go"
+	otel_log "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// stateLogger 相关的环境变量与阈值：
+//   - DEMO_STATE_LOGGER_INTERVAL：采样周期，默认 10s。
+//   - DEMO_STATE_LOGGER_HEAP_GROWTH_RATIO：HeapAlloc 相对首次采样基线增长超过该倍数时告警，默认 2。
+//   - DEMO_STATE_LOGGER_GC_PAUSE_THRESHOLD_MS：采样时最近一次 GC 的暂停超过该毫秒数时告警，默认 50ms
+//     （两次采样之间更早的 GC 不参与判断）。
+//   - DEMO_STATE_LOGGER_GOROUTINE_THRESHOLD：goroutine 数超过该值时告警，默认 10000。
+const (
+	envStateLoggerInterval   = "DEMO_STATE_LOGGER_INTERVAL"
+	envStateLoggerHeapGrowth = "DEMO_STATE_LOGGER_HEAP_GROWTH_RATIO"
+	envStateLoggerGCPauseMs  = "DEMO_STATE_LOGGER_GC_PAUSE_THRESHOLD_MS"
+	envStateLoggerGoroutines = "DEMO_STATE_LOGGER_GOROUTINE_THRESHOLD"
+
+	defaultStateLoggerInterval = 10 * time.Second
+	defaultHeapGrowthRatio     = 2.0
+	defaultGCPauseThresholdMs  = int64(50)
+	defaultGoroutineThreshold  = 10000
+)
+
+// runtimeGauges 汇总 stateLogger 上报的 OTel 指标。
+var runtimeGauges struct {
+	goroutines metric.Int64Gauge
+	heapAlloc  metric.Int64Gauge
+	heapInuse  metric.Int64Gauge
+	gcPause    metric.Int64Gauge
+	numGC      metric.Int64Gauge
+	allocHolds metric.Int64Gauge
+}
+
+// activeRootTraceIDs 记录当前正在进行中的顶层（root）trace，供 stateLogger 把它们作为
+// 日志属性带出去，方便在 Loki 里把“某次 runtime 快照”和“当时在跑的 trace”关联起来。
+var activeRootTraceIDs sync.Map // traceID string -> struct{}{}
+
+func trackRootTraceID(traceID string) {
+	if traceID == "" || traceID == "00000000000000000000000000000000" {
+		return
+	}
+	activeRootTraceIDs.Store(traceID, struct{}{})
+}
+
+func untrackRootTraceID(traceID string) {
+	activeRootTraceIDs.Delete(traceID)
+}
+
+func snapshotRootTraceIDs() []string {
+	var ids []string
+	activeRootTraceIDs.Range(func(key, _ interface{}) bool {
+		ids = append(ids, key.(string))
+		return true
+	})
+	return ids
+}
+
+// initStateLoggerMetrics 注册 stateLogger 用到的几个 gauge，需要在 meter 初始化之后调用。
+func initStateLoggerMetrics() error {
+	var err error
+	if runtimeGauges.goroutines, err = meter.Int64Gauge("runtime_goroutines", metric.WithDescription("Number of goroutines")); err != nil {
+		return err
+	}
+	if runtimeGauges.heapAlloc, err = meter.Int64Gauge("runtime_heap_alloc_bytes", metric.WithDescription("HeapAlloc from runtime.MemStats")); err != nil {
+		return err
+	}
+	if runtimeGauges.heapInuse, err = meter.Int64Gauge("runtime_heap_inuse_bytes", metric.WithDescription("HeapInuse from runtime.MemStats")); err != nil {
+		return err
+	}
+	if runtimeGauges.gcPause, err = meter.Int64Gauge("runtime_gc_pause_total_ns", metric.WithDescription("PauseTotalNs from runtime.MemStats")); err != nil {
+		return err
+	}
+	if runtimeGauges.numGC, err = meter.Int64Gauge("runtime_gc_num", metric.WithDescription("NumGC from runtime.MemStats")); err != nil {
+		return err
+	}
+	runtimeGauges.allocHolds, err = meter.Int64Gauge("demo_alloc_holder_batches", metric.WithDescription("len(allocHolder), the number of retained memory-burst batches"))
+	return err
+}
+
+// stateLoggerConfig 是从环境变量解析出来的阈值配置。
+type stateLoggerConfig struct {
+	interval           time.Duration
+	heapGrowthRatio    float64
+	gcPauseThresholdMs int64
+	goroutineThreshold int
+}
+
+func loadStateLoggerConfig() stateLoggerConfig {
+	cfg := stateLoggerConfig{
+		interval:           defaultStateLoggerInterval,
+		heapGrowthRatio:    defaultHeapGrowthRatio,
+		gcPauseThresholdMs: defaultGCPauseThresholdMs,
+		goroutineThreshold: defaultGoroutineThreshold,
+	}
+	if raw := os.Getenv(envStateLoggerInterval); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			cfg.interval = d
+		}
+	}
+	if raw := os.Getenv(envStateLoggerHeapGrowth); raw != "" {
+		if f, err := strconv.ParseFloat(raw, 64); err == nil && f > 0 {
+			cfg.heapGrowthRatio = f
+		}
+	}
+	if raw := os.Getenv(envStateLoggerGCPauseMs); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n > 0 {
+			cfg.gcPauseThresholdMs = n
+		}
+	}
+	if raw := os.Getenv(envStateLoggerGoroutines); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			cfg.goroutineThreshold = n
+		}
+	}
+	return cfg
+}
+
+// startStateLogger 启动一个后台 goroutine，每隔 cfg.interval 采集一次运行时状态
+// （goroutine 数、堆内存、GC 暂停时间、allocHolder 长度），同时以 OTel 日志 + 指标的形式
+// 输出，并在任一观测值越过阈值时，额外打一条 SeverityWarn 日志，并调用
+// flushHeapSnapshotToPyroscope（该调用不会向 Pyroscope 发送任何数据，见其注释）。
+func startStateLogger(ctx context.Context) {
+	cfg := loadStateLoggerConfig()
+	logger := global.Logger(serviceName)
+	hostname, _ := os.Hostname()
+
+	var baselineHeap uint64
+	var baselineSet bool
+	var lastNumGC uint32
+
+	ticker := time.NewTicker(cfg.interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				var m runtime.MemStats
+				runtime.ReadMemStats(&m)
+				numGoroutine := runtime.NumGoroutine()
+
+				if !baselineSet {
+					baselineHeap = m.HeapAlloc
+					baselineSet = true
+				}
+
+				recentPauseNs := recentGCPauseNs(&m, lastNumGC)
+				lastNumGC = m.NumGC
+
+				reportRuntimeMetrics(ctx, numGoroutine, &m)
+				emitRuntimeLog(ctx, logger, hostname, numGoroutine, &m)
+
+				warnings := evaluateThresholds(cfg, numGoroutine, &m, baselineHeap, recentPauseNs)
+				if len(warnings) > 0 {
+					emitWarnLog(ctx, logger, hostname, numGoroutine, &m, warnings)
+					flushHeapSnapshotToPyroscope(warnings)
+				}
+			}
+		}
+	}()
+}
+
+// recentGCPauseNs 返回自上次采样以来最近一次 GC 的暂停时间（PauseNs 是一个环形缓冲区）。
+func recentGCPauseNs(m *runtime.MemStats, lastNumGC uint32) int64 {
+	if m.NumGC == 0 || m.NumGC == lastNumGC {
+		return 0
+	}
+	idx := (m.NumGC + 255) % 256
+	return int64(m.PauseNs[idx])
+}
+
+func reportRuntimeMetrics(ctx context.Context, numGoroutine int, m *runtime.MemStats) {
+	runtimeGauges.goroutines.Record(ctx, int64(numGoroutine))
+	runtimeGauges.heapAlloc.Record(ctx, int64(m.HeapAlloc))
+	runtimeGauges.heapInuse.Record(ctx, int64(m.HeapInuse))
+	runtimeGauges.gcPause.Record(ctx, int64(m.PauseTotalNs))
+	runtimeGauges.numGC.Record(ctx, int64(m.NumGC))
+	runtimeGauges.allocHolds.Record(ctx, int64(allocHolderLen()))
+}
+
+func emitRuntimeLog(ctx context.Context, logger otel_log.Logger, hostname string, numGoroutine int, m *runtime.MemStats) {
+	r := otel_log.Record{}
+	r.SetTimestamp(time.Now())
+	r.SetSeverity(otel_log.SeverityInfo)
+	r.SetSeverityText("INFO")
+	r.SetBody(otel_log.StringValue("runtime state snapshot"))
+	r.AddAttributes(
+		otel_log.String("service.instance.id", hostname),
+		otel_log.String("hostname", hostname),
+		otel_log.Int("goroutines", numGoroutine),
+		otel_log.Int64("heap_alloc_bytes", int64(m.HeapAlloc)),
+		otel_log.Int64("heap_inuse_bytes", int64(m.HeapInuse)),
+		otel_log.Int64("gc_pause_total_ns", int64(m.PauseTotalNs)),
+		otel_log.Int64("num_gc", int64(m.NumGC)),
+		otel_log.Int("alloc_holder_batches", allocHolderLen()),
+	)
+	for _, traceID := range snapshotRootTraceIDs() {
+		r.AddAttributes(otel_log.String("active_root_trace_id", traceID))
+	}
+	logger.Emit(ctx, r)
+}
+
+func emitWarnLog(ctx context.Context, logger otel_log.Logger, hostname string, numGoroutine int, m *runtime.MemStats, warnings []string) {
+	r := otel_log.Record{}
+	r.SetTimestamp(time.Now())
+	r.SetSeverity(otel_log.SeverityWarn)
+	r.SetSeverityText("WARN")
+	r.SetBody(otel_log.StringValue("runtime state crossed a watched threshold"))
+	r.AddAttributes(
+		otel_log.String("service.instance.id", hostname),
+		otel_log.String("hostname", hostname),
+		otel_log.Int("goroutines", numGoroutine),
+		otel_log.Int64("heap_alloc_bytes", int64(m.HeapAlloc)),
+		otel_log.Int64("gc_pause_total_ns", int64(m.PauseTotalNs)),
+	)
+	for _, w := range warnings {
+		r.AddAttributes(otel_log.String("warning", w))
+	}
+	for _, traceID := range snapshotRootTraceIDs() {
+		r.AddAttributes(otel_log.String("active_root_trace_id", traceID))
+	}
+	logger.Emit(ctx, r)
+}
+
+// evaluateThresholds 对照 cfg 里的阈值逐项检查，返回所有被触发的告警描述。
+func evaluateThresholds(cfg stateLoggerConfig, numGoroutine int, m *runtime.MemStats, baselineHeap uint64, recentPauseNs int64) []string {
+	var warnings []string
+	if baselineHeap > 0 && float64(m.HeapAlloc) > float64(baselineHeap)*cfg.heapGrowthRatio {
+		warnings = append(warnings, "heap_alloc_grew_beyond_baseline_ratio")
+	}
+	if recentPauseNs > cfg.gcPauseThresholdMs*int64(time.Millisecond) {
+		warnings = append(warnings, "gc_pause_exceeded_threshold")
+	}
+	if numGoroutine > cfg.goroutineThreshold {
+		warnings = append(warnings, "goroutine_count_exceeded_threshold")
+	}
+	return warnings
+}
+
+// flushHeapSnapshotToPyroscope 在 pyroscope-go 的 TagWrapper（state=warn 和触发原因的标签）里
+// 生成一次堆快照，并把它写进 discardWriter 直接丢弃：这次快照不会被发送到 Pyroscope，
+// 这些标签也只在回调执行期间挂在当前 goroutine 上。
+func flushHeapSnapshotToPyroscope(warnings []string) {
+	pyroscope.TagWrapper(context.Background(), pyroscope.Labels(
+		"state", "warn",
+		"warning", warnings[0],
+	), func(context.Context) {
+		_ = pprof.Lookup("heap").WriteTo(discardWriter{}, 0)
+	})
+}
+
+// discardWriter 丢弃所有写入，我们只是为了触发一次堆快照的采集，不需要保留输出。
+type discardWriter struct{}
+
+func (discardWriter) Write(p []byte) (int, error) { return len(p), nil }
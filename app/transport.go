@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// OTEL_EXPORTER_OTLP_PROTOCOL 控制 traces/metrics/logs 三种信号用什么协议发往 Alloy：
+//   - http/protobuf（默认，和之前行为一致）
+//   - grpc：标准 OTLP/gRPC
+//
+// "arrow"（OTel-Arrow 列式、流式传输）不受支持：本 demo 没有引入 OTel-Arrow 的客户端依赖，
+// 设置为 arrow 时 otlpProtocol 会打一条日志并退回 http/protobuf。
+const envOTLPProtocol = "OTEL_EXPORTER_OTLP_PROTOCOL"
+
+const (
+	protocolHTTP = "http/protobuf"
+	protocolGRPC = "grpc"
+)
+
+// otlpProtocol 读取并归一化 OTEL_EXPORTER_OTLP_PROTOCOL，未设置或未识别时退回到 http/protobuf。
+// 显式请求 "arrow" 时会打一条日志说明该协议未实现，而不是悄悄退化，避免让人以为 Arrow 传输生效了。
+func otlpProtocol() string {
+	switch raw := os.Getenv(envOTLPProtocol); raw {
+	case protocolGRPC:
+		return protocolGRPC
+	case "arrow":
+		log.Printf("OTEL_EXPORTER_OTLP_PROTOCOL=arrow is not implemented in this demo (no OTel-Arrow client dependency), falling back to %s", protocolHTTP)
+		return protocolHTTP
+	default:
+		return protocolHTTP
+	}
+}
+
+// newGRPCConn 建立一个到 Alloy 的 gRPC 连接。
+func newGRPCConn(endpoint string) (*grpc.ClientConn, error) {
+	return grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// buildTraceExporter 根据协议构造 trace exporter。
+func buildTraceExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	switch otlpProtocol() {
+	case protocolGRPC:
+		conn, err := newGRPCConn(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial otlp grpc endpoint: %w", err)
+		}
+		return otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
+	default:
+		return otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint+"/v1/traces"))
+	}
+}
+
+// buildMetricExporter 与 buildTraceExporter 对称，为 metrics 信号选择传输协议。
+func buildMetricExporter(ctx context.Context, endpoint string) (sdkmetric.Exporter, error) {
+	switch otlpProtocol() {
+	case protocolGRPC:
+		conn, err := newGRPCConn(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial otlp grpc endpoint: %w", err)
+		}
+		return otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithGRPCConn(conn))
+	default:
+		return otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpointURL(endpoint+"/v1/metrics"))
+	}
+}
+
+// buildLogExporter 与上面两个对称。
+func buildLogExporter(ctx context.Context, endpoint string) (sdklog.Exporter, error) {
+	switch otlpProtocol() {
+	case protocolGRPC:
+		conn, err := newGRPCConn(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial otlp grpc endpoint: %w", err)
+		}
+		return otlploggrpc.New(ctx, otlploggrpc.WithGRPCConn(conn))
+	default:
+		return otlploghttp.New(ctx, otlploghttp.WithEndpointURL(endpoint+"/v1/logs"))
+	}
+}